@@ -0,0 +1,145 @@
+package couchdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPutAttachment(t *testing.T) {
+	var gotContentType string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		w.Header().Set("Etag", `"1-abc"`)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rev, err := c.DB("db").PutAttachment("doc1", "", "foo.txt", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev != "1-abc" {
+		t.Errorf("rev = %q, want 1-abc", rev)
+	}
+	if gotContentType != "text/plain" {
+		t.Errorf("Content-Type = %q, want text/plain", gotContentType)
+	}
+	if string(gotBody) != "hello" {
+		t.Errorf("body = %q, want hello", gotBody)
+	}
+}
+
+func TestPutWithAttachments(t *testing.T) {
+	var gotContentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		io.Copy(ioutil.Discard, r.Body)
+		w.Header().Set("Etag", `"2-def"`)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := map[string]string{"name": "alice"}
+	atts := []Attachment{{Name: "foo.txt", ContentType: "text/plain", Body: strings.NewReader("hello")}}
+	rev, err := c.DB("db").PutWithAttachments("doc1", doc, atts, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev != "2-def" {
+		t.Errorf("rev = %q, want 2-def", rev)
+	}
+	if !strings.HasPrefix(gotContentType, "multipart/related") {
+		t.Errorf("Content-Type = %q, want multipart/related", gotContentType)
+	}
+}
+
+func TestGetWithAttachmentsMultipart(t *testing.T) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	docPart, err := w.CreatePart(map[string][]string{"Content-Type": {"application/json"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docPart.Write([]byte(`{"_id":"doc1","name":"alice"}`))
+
+	attPart, err := w.CreatePart(map[string][]string{
+		"Content-Type":        {"text/plain"},
+		"Content-Disposition": {`attachment; filename="foo.txt"`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attPart.Write([]byte("hello"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "multipart/related; boundary="+w.Boundary())
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md, err := c.DB("db").GetWithAttachments("doc1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		ID   string `json:"_id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(md.Doc, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Name != "alice" {
+		t.Errorf("doc.Name = %q, want alice", doc.Name)
+	}
+
+	name, contentType, body, err := md.Attachments.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "foo.txt" {
+		t.Errorf("name = %q, want foo.txt", name)
+	}
+	if contentType != "text/plain" {
+		t.Errorf("contentType = %q, want text/plain", contentType)
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("body = %q, want hello", data)
+	}
+
+	if _, _, _, err := md.Attachments.Next(); err != io.EOF {
+		t.Errorf("Next() err = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,25 @@
+package couchdb
+
+import "net/http"
+
+// Auth is the interface implemented by authentication mechanisms.
+// AddAuth is called for every outgoing request; implementations add
+// whatever headers or cookies are required and return an error if
+// authentication is not currently possible.
+type Auth interface {
+	AddAuth(req *http.Request) error
+}
+
+type basicAuth struct {
+	username, password string
+}
+
+// BasicAuth implements HTTP Basic Authentication.
+func BasicAuth(username, password string) Auth {
+	return &basicAuth{username, password}
+}
+
+func (a *basicAuth) AddAuth(req *http.Request) error {
+	req.SetBasicAuth(a.username, a.password)
+	return nil
+}
@@ -0,0 +1,174 @@
+package couchdb
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// cookieAuth implements cookie-based authentication as described in
+// http://docs.couchdb.org/en/latest/api/server/authn.html#cookie-authentication.
+//
+// The first request made with a cookieAuth logs in against the
+// server's /_session endpoint and caches the resulting AuthSession
+// cookie; later requests reuse it. If the server rejects a request
+// with 401, the cached session is discarded and the request is sent
+// again after a fresh login; a refreshed AuthSession cookie in any
+// response replaces the cached one. A cookieAuth may be shared between
+// goroutines: logins are serialized behind a mutex, so concurrent
+// requests that find no cached session wait for a single login.
+// Requests that each received a 401 for the same expired session each
+// discard the cached session, so that case can cause more than one
+// login.
+type cookieAuth struct {
+	Username string
+	Password string
+
+	mu      sync.Mutex
+	baseURL string
+	session string
+	rt      http.RoundTripper
+}
+
+// CookieAuth creates a cookie-based Auth mechanism for the given
+// credentials. Logout can be used to end the session.
+//
+// Once passed to Client.SetAuth, login and logout requests are sent
+// through the same http.RoundTripper as every other request the client
+// makes; until then they use http.DefaultTransport.
+func CookieAuth(username, password string) *cookieAuth {
+	return &cookieAuth{Username: username, Password: password, rt: http.DefaultTransport}
+}
+
+// setRoundTripper implements roundTripperSetter so login/Logout use the
+// same RoundTripper as the rest of the client.
+func (a *cookieAuth) setRoundTripper(rt http.RoundTripper) {
+	a.mu.Lock()
+	a.rt = rt
+	a.mu.Unlock()
+}
+
+// AddAuth attaches the cached AuthSession cookie to req, logging in
+// first if there is no cached session yet.
+func (a *cookieAuth) AddAuth(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.baseURL == "" {
+		a.baseURL = req.URL.Scheme + "://" + req.URL.Host
+	}
+	if a.session == "" {
+		if err := a.login(); err != nil {
+			return err
+		}
+	}
+	req.AddCookie(&http.Cookie{Name: "AuthSession", Value: a.session})
+	return nil
+}
+
+// invalidate discards the cached session so the next request logs in
+// again. It implements the reauthenticator interface used by
+// transport to recover from a 401.
+func (a *cookieAuth) invalidate() {
+	a.mu.Lock()
+	a.session = ""
+	a.mu.Unlock()
+}
+
+// updateFromResponse picks up a refreshed AuthSession cookie from an
+// ordinary API response, so a session renewed by the server is used on
+// the next request without waiting for a 401. It implements the
+// sessionUpdater interface used by transport.
+func (a *cookieAuth) updateFromResponse(resp *http.Response) {
+	for _, c := range resp.Cookies() {
+		if c.Name == "AuthSession" && c.Value != "" {
+			a.mu.Lock()
+			a.session = c.Value
+			a.mu.Unlock()
+			return
+		}
+	}
+}
+
+// login must be called with a.mu held.
+func (a *cookieAuth) login() error {
+	form := url.Values{"name": {a.Username}, "password": {a.Password}}
+	resp, err := (&http.Client{Transport: a.rt}).PostForm(a.baseURL+"/_session", form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("couchdb: _session login failed with status %d", resp.StatusCode)
+	}
+	for _, c := range resp.Cookies() {
+		if c.Name == "AuthSession" {
+			a.session = c.Value
+			return nil
+		}
+	}
+	return errors.New("couchdb: _session response did not set an AuthSession cookie")
+}
+
+// Logout ends the current session by deleting it on the server. It is
+// a no-op if no session has been established yet.
+func (a *cookieAuth) Logout() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.baseURL == "" || a.session == "" {
+		a.session = ""
+		return nil
+	}
+	req, err := http.NewRequest("DELETE", a.baseURL+"/_session", nil)
+	if err != nil {
+		return err
+	}
+	req.AddCookie(&http.Cookie{Name: "AuthSession", Value: a.session})
+	resp, err := (&http.Client{Transport: a.rt}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	a.session = ""
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("couchdb: _session logout failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// proxyAuth implements the X-Auth-CouchDB-* proxy authentication
+// headers described in
+// http://docs.couchdb.org/en/latest/api/server/authn.html#proxy-authentication.
+type proxyAuth struct {
+	username string
+	roles    []string
+	secret   string
+}
+
+// ProxyAuth creates an Auth mechanism that authenticates via the
+// X-Auth-CouchDB-UserName, X-Auth-CouchDB-Roles and (if secret is
+// non-empty) X-Auth-CouchDB-Token headers. This requires the server to
+// be configured with a matching [couch_httpd_auth] proxy_use_secret.
+func ProxyAuth(username string, roles []string, secret string) Auth {
+	return &proxyAuth{username, roles, secret}
+}
+
+func (a *proxyAuth) AddAuth(req *http.Request) error {
+	req.Header.Set("X-Auth-CouchDB-UserName", a.username)
+	req.Header.Set("X-Auth-CouchDB-Roles", strings.Join(a.roles, ","))
+	if a.secret != "" {
+		mac := hmac.New(sha1.New, []byte(a.secret))
+		mac.Write([]byte(a.username))
+		req.Header.Set("X-Auth-CouchDB-Token", hex.EncodeToString(mac.Sum(nil)))
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package couchdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// recordingRoundTripper wraps http.DefaultTransport and records the
+// path of every request it sees, so tests can check whether a given
+// request went through it.
+type recordingRoundTripper struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (r *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.paths = append(r.paths, req.URL.Path)
+	r.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (r *recordingRoundTripper) saw(path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+func newCookieTestServer(t *testing.T, sessionCalls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_session" {
+			if r.Method == "POST" {
+				atomic.AddInt32(sessionCalls, 1)
+				http.SetCookie(w, &http.Cookie{Name: "AuthSession", Value: "tok"})
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+}
+
+func TestCookieAuthUsesClientRoundTripper(t *testing.T) {
+	var sessionCalls int32
+	srv := newCookieTestServer(t, &sessionCalls)
+	defer srv.Close()
+
+	rt := &recordingRoundTripper{}
+	c, err := NewClient(srv.URL, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetAuth(CookieAuth("user", "pass"))
+
+	var doc map[string]interface{}
+	if err := c.DB("db").Get("doc1", &doc, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if atomic.LoadInt32(&sessionCalls) != 1 {
+		t.Fatalf("got %d _session calls, want 1", sessionCalls)
+	}
+	if !rt.saw("/_session") {
+		t.Errorf("login request did not go through the client's RoundTripper")
+	}
+}
+
+func TestCookieAuthLoginSingleFlighted(t *testing.T) {
+	var sessionCalls int32
+	srv := newCookieTestServer(t, &sessionCalls)
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetAuth(CookieAuth("user", "pass"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var doc map[string]interface{}
+			if err := c.DB("db").Get("doc1", &doc, nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&sessionCalls); got != 1 {
+		t.Errorf("got %d _session calls, want 1 (login should be single-flighted)", got)
+	}
+}
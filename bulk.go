@@ -0,0 +1,245 @@
+package couchdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// BulkDoc wraps a document for submission to BulkDocs. ID and Rev are only
+// required when the caller needs an explicit document ID or is updating an
+// existing revision; CouchDB will assign an ID when ID is empty.
+type BulkDoc struct {
+	ID       string `json:"_id,omitempty"`
+	Rev      string `json:"_rev,omitempty"`
+	Deleted  bool   `json:"_deleted,omitempty"`
+	Document interface{}
+}
+
+// MarshalJSON encodes d as Document's fields with _id/_rev/_deleted
+// merged in, the form _bulk_docs expects. It fails if Document does not
+// marshal to a JSON object.
+func (d BulkDoc) MarshalJSON() ([]byte, error) {
+	body, err := bulkDocBody(&d)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(body)
+}
+
+// BulkResult is the per-document outcome of a BulkDocs call.
+// Error and Reason are set instead of Rev when CouchDB rejected
+// the individual document, e.g. with a 409 conflict; a caller can
+// range over the results and retry just the failed subset instead
+// of treating the whole batch as failed.
+type BulkResult struct {
+	ID     string `json:"id"`
+	Rev    string `json:"rev,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Ok reports whether the document was stored successfully.
+func (r BulkResult) Ok() bool {
+	return r.Error == ""
+}
+
+// BulkRef identifies a single document to retrieve with BulkGet.
+// Rev may be left empty to fetch the winning revision.
+type BulkRef struct {
+	ID  string `json:"id"`
+	Rev string `json:"rev,omitempty"`
+}
+
+// AllOrNothing returns an Options value that sets the "all_or_nothing"
+// option for BulkDocs.
+func AllOrNothing(v bool) Options {
+	return Options{"all_or_nothing": v}
+}
+
+type bulkDocsRequest struct {
+	Docs         []interface{} `json:"docs"`
+	AllOrNothing bool          `json:"all_or_nothing,omitempty"`
+	NewEdits     *bool         `json:"new_edits,omitempty"`
+}
+
+type bulkGetRequest struct {
+	Docs []BulkRef `json:"docs"`
+}
+
+type bulkGetResponse struct {
+	Results []struct {
+		ID   string `json:"id"`
+		Docs []struct {
+			OK    *json.RawMessage `json:"ok"`
+			Error *BulkResult      `json:"error"`
+		} `json:"docs"`
+	} `json:"results"`
+}
+
+// BulkDocs submits a batch of documents to the database's _bulk_docs
+// endpoint in a single HTTP round trip. Each element of docs is
+// marshalled as-is, so callers may pass either plain structs/maps (for
+// new documents) or a BulkDoc to control _id/_rev/_deleted explicitly.
+//
+// The returned slice has one BulkResult per input document, in the same
+// order; a per-document conflict (409) is reported in that document's
+// Error/Reason fields rather than as the overall error return. The
+// overall error is only set for request-level failures, e.g. a network
+// error or a malformed request.
+//
+// If opts contains the AllOrNothing option set to true (see
+// AllOrNothing), CouchDB disables its own conflict detection and
+// stores every revision unconditionally.
+//
+// http://docs.couchdb.org/en/latest/api/database/bulk-api.html#db-bulk-docs
+func (db *DB) BulkDocs(docs []interface{}, opts Options) ([]BulkResult, error) {
+	body := bulkDocsRequest{Docs: docs}
+	if allOrNothing, ok := opts["all_or_nothing"].(bool); ok {
+		body.AllOrNothing = allOrNothing
+	}
+	if newEdits, ok := opts["new_edits"].(bool); ok {
+		body.NewEdits = &newEdits
+	}
+	enc, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := db.request("POST", path(db.name, "_bulk_docs"), bytes.NewReader(enc))
+	if err != nil {
+		return nil, err
+	}
+	var results []BulkResult
+	if err := readBody(resp, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// BulkGet retrieves a batch of documents in a single HTTP round trip via
+// the database's _bulk_get endpoint. The returned rows are in the same
+// order as refs; a document that could not be fetched (e.g. deleted, or
+// a missing revision) yields a Row with HasDoc() == false.
+//
+// http://docs.couchdb.org/en/latest/api/database/bulk-api.html#db-bulk-get
+func (db *DB) BulkGet(refs []BulkRef, opts Options) ([]Row, error) {
+	enc, err := json.Marshal(bulkGetRequest{Docs: refs})
+	if err != nil {
+		return nil, err
+	}
+	p, err := optpath(opts, nil, db.name, "_bulk_get")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := db.request("POST", p, bytes.NewReader(enc))
+	if err != nil {
+		return nil, err
+	}
+	var parsed bulkGetResponse
+	if err := readBody(resp, &parsed); err != nil {
+		return nil, err
+	}
+	rows := make([]Row, len(parsed.Results))
+	for i, r := range parsed.Results {
+		rows[i].ID = r.ID
+		if len(r.Docs) > 0 && r.Docs[0].OK != nil {
+			rows[i].Doc_ = r.Docs[0].OK
+		}
+	}
+	return rows, nil
+}
+
+// BulkUpsert stores docs with BulkDocs and automatically retries the
+// conflicted subset: for every document that comes back with a 409
+// conflict error, the current revision is fetched with Rev and the
+// retry is re-submitted with that revision set, up to maxRetries times.
+// This is the pattern high-throughput callers use instead of a Get
+// before every Put: optimistic writes that only pay the extra round
+// trip for documents that actually collided.
+//
+// docs must implement a way to read and set their own "_id" and "_rev"
+// fields; BulkUpsert accepts []*BulkDoc for that reason rather than
+// arbitrary interface{} values. Note that a retried document's Rev is
+// updated in place on the *BulkDoc the caller passed in, not on a copy;
+// callers that need to keep their original BulkDoc values untouched
+// should pass copies.
+func (db *DB) BulkUpsert(docs []*BulkDoc, maxRetries int) ([]BulkResult, error) {
+	pending := make([]*BulkDoc, len(docs))
+	copy(pending, docs)
+	final := make(map[int]BulkResult, len(docs))
+	index := make([]int, len(docs))
+	for i := range docs {
+		index[i] = i
+	}
+
+	for attempt := 0; attempt <= maxRetries && len(pending) > 0; attempt++ {
+		payload := make([]interface{}, len(pending))
+		for i, d := range pending {
+			body, err := bulkDocBody(d)
+			if err != nil {
+				return nil, err
+			}
+			payload[i] = body
+		}
+		results, err := db.BulkDocs(payload, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var retry []*BulkDoc
+		var retryIndex []int
+		for i, r := range results {
+			if r.Ok() || r.Error != "conflict" {
+				final[index[i]] = r
+				continue
+			}
+			d := pending[i]
+			rev, err := db.Rev(d.ID)
+			if err != nil {
+				final[index[i]] = r
+				continue
+			}
+			d.Rev = rev
+			retry = append(retry, d)
+			retryIndex = append(retryIndex, index[i])
+		}
+		pending, index = retry, retryIndex
+	}
+	for i, d := range pending {
+		final[index[i]] = BulkResult{ID: d.ID, Error: "conflict", Reason: "max retries exceeded"}
+	}
+
+	out := make([]BulkResult, len(docs))
+	for i := range docs {
+		out[i] = final[i]
+	}
+	return out, nil
+}
+
+// bulkDocBody merges d's _id/_rev/_deleted into its Document so
+// BulkUpsert's retries carry the revision they just fetched. Document
+// must marshal to a JSON object for that merge to be possible; it is
+// an error otherwise, since silently submitting d.Document unmodified
+// would drop _id/_rev and could turn an intended update into an
+// accidental insert.
+func bulkDocBody(d *BulkDoc) (interface{}, error) {
+	enc, err := json.Marshal(d.Document)
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) == 0 || enc[0] != '{' {
+		return nil, fmt.Errorf("couchdb: BulkUpsert: document %q does not marshal to a JSON object, so _id/_rev cannot be merged in", d.ID)
+	}
+	merged := map[string]interface{}{}
+	json.Unmarshal(enc, &merged)
+	if d.ID != "" {
+		merged["_id"] = d.ID
+	}
+	if d.Rev != "" {
+		merged["_rev"] = d.Rev
+	}
+	if d.Deleted {
+		merged["_deleted"] = true
+	}
+	return merged, nil
+}
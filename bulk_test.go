@@ -0,0 +1,122 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBulkDocs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var req bulkDocsRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatal(err)
+		}
+		if len(req.Docs) != 2 {
+			t.Fatalf("got %d docs, want 2", len(req.Docs))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":"doc1","rev":"1-a"},{"id":"doc2","error":"conflict","reason":"Document update conflict."}]`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := c.DB("db").BulkDocs([]interface{}{
+		&BulkDoc{ID: "doc1", Document: map[string]string{"a": "1"}},
+		&BulkDoc{ID: "doc2", Document: map[string]string{"a": "2"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if !results[0].Ok() || results[0].Rev != "1-a" {
+		t.Errorf("results[0] = %+v", results[0])
+	}
+	if results[1].Ok() || results[1].Error != "conflict" {
+		t.Errorf("results[1] = %+v", results[1])
+	}
+}
+
+// TestBulkUpsertRetriesConflict exercises BulkUpsert's conflict-retry
+// path: the first _bulk_docs call reports a conflict, BulkUpsert fetches
+// the current rev with a HEAD request, and resubmits with it set.
+func TestBulkUpsertRetriesConflict(t *testing.T) {
+	var bulkCalls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "HEAD":
+			w.Header().Set("Etag", `"2-current"`)
+			w.WriteHeader(http.StatusOK)
+		case r.Method == "POST":
+			bulkCalls++
+			body, _ := ioutil.ReadAll(r.Body)
+			var req bulkDocsRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Fatal(err)
+			}
+			doc := req.Docs[0].(map[string]interface{})
+			if bulkCalls == 1 {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[{"id":"doc1","error":"conflict","reason":"Document update conflict."}]`))
+				return
+			}
+			if doc["_rev"] != "2-current" {
+				t.Errorf("retry _rev = %v, want 2-current", doc["_rev"])
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"id":"doc1","rev":"3-new"}]`))
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs := []*BulkDoc{{ID: "doc1", Document: map[string]string{"a": "1"}}}
+	results, err := c.DB("db").BulkUpsert(docs, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bulkCalls != 2 {
+		t.Fatalf("got %d _bulk_docs calls, want 2", bulkCalls)
+	}
+	if !results[0].Ok() || results[0].Rev != "3-new" {
+		t.Errorf("results[0] = %+v", results[0])
+	}
+	if docs[0].Rev != "2-current" {
+		t.Errorf("docs[0].Rev = %q, want 2-current (BulkUpsert mutates its input in place)", docs[0].Rev)
+	}
+}
+
+// TestBulkUpsertNonObjectDocument verifies that a Document which cannot
+// be merged with _id/_rev fails loudly instead of silently being
+// submitted without them.
+func TestBulkUpsertNonObjectDocument(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("server should not be contacted")
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs := []*BulkDoc{{ID: "doc1", Document: "not an object"}}
+	if _, err := c.DB("db").BulkUpsert(docs, 0); err == nil {
+		t.Fatal("expected an error for a non-object Document")
+	}
+}
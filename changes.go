@@ -0,0 +1,291 @@
+package couchdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Change represents a single entry of the _changes feed.
+type Change struct {
+	Seq     json.RawMessage  `json:"seq"`
+	ID      string           `json:"id"`
+	Deleted bool             `json:"deleted"`
+	Changes []ChangeRev      `json:"changes"`
+	Doc_    *json.RawMessage `json:"doc"`
+}
+
+// ChangeRev is one entry of a Change's Changes list.
+type ChangeRev struct {
+	Rev string `json:"rev"`
+}
+
+// HasDoc reports whether the change carries an embedded document,
+// which is only present when the request set the "include_docs" option.
+func (c Change) HasDoc() bool {
+	return c.Doc_ != nil
+}
+
+// Doc unmarshals the embedded document into v.
+func (c Change) Doc(v interface{}) error {
+	if c.Doc_ == nil {
+		return errors.New("change has no doc.")
+	}
+	return json.Unmarshal(*c.Doc_, v)
+}
+
+var changesJsonKeys = []string{"doc_ids"}
+
+// Changes opens the database's _changes feed.
+// opts supports the usual query options plus "feed" ("normal",
+// "longpoll", or "continuous"; default "normal"), "since" (a sequence
+// value or the string "now"), "heartbeat", "filter", "doc_ids" and
+// "include_docs". For feed=="continuous" the connection is kept open and
+// Scan blocks until the next change arrives or the feed ends or is
+// closed; heartbeat lines are skipped and do not make Scan return.
+// Callers should always defer Close() to release the underlying
+// connection.
+//
+// http://docs.couchdb.org/en/latest/api/database/changes.html
+func (db *DB) Changes(opts Options) (*ChangeScanner, error) {
+	feed, _ := opts["feed"].(string)
+	p, err := optpath(opts, changesJsonKeys, db.name, "_changes")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := db.request("GET", p, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status code: %d", resp.StatusCode)
+	}
+	return newChangeScanner(resp, feed == "continuous"), nil
+}
+
+// ChangeScanner reads entries off a _changes feed. Its API mirrors
+// RowScanner: call Scan in a loop, retrieve the current entry with
+// Change, and check Err once Scan returns false.
+type ChangeScanner struct {
+	resp *http.Response
+
+	change  Change
+	lastSeq json.RawMessage
+
+	quit    chan struct{}
+	changes chan Change
+
+	mu  *sync.Mutex
+	err error
+}
+
+func newChangeScanner(resp *http.Response, continuous bool) *ChangeScanner {
+	s := &ChangeScanner{
+		resp:    resp,
+		quit:    make(chan struct{}),
+		changes: make(chan Change),
+		mu:      new(sync.Mutex),
+	}
+	if continuous {
+		go s.readContinuous()
+	} else {
+		go s.readEnveloped()
+	}
+	return s
+}
+
+// Close releases the underlying HTTP connection. It is safe to call
+// more than once.
+func (s *ChangeScanner) Close() error {
+	select {
+	case <-s.quit:
+	default:
+		close(s.quit)
+		// Closing the body unblocks a read in progress, which on a
+		// continuous feed would otherwise wait for more data.
+		s.resp.Body.Close()
+	}
+	return s.Err()
+}
+
+// LastSeq returns the "last_seq" value sent at the end of the feed
+// (for a continuous feed, its terminating {"last_seq":...} line). It is
+// only meaningful once Scan has returned false.
+func (s *ChangeScanner) LastSeq() json.RawMessage {
+	return s.lastSeq
+}
+
+func (s *ChangeScanner) closeBody() {
+	io.Copy(ioutil.Discard, s.resp.Body)
+	s.resp.Body.Close()
+}
+
+// readEnveloped parses the normal/longpoll framing:
+// {"results":[ {...},\n {...} ],"last_seq":...}
+func (s *ChangeScanner) readEnveloped() {
+	defer close(s.changes)
+	defer s.closeBody()
+
+	b := bufio.NewReader(s.resp.Body)
+	if _, err := b.ReadBytes(delim); err != nil { // {"results":[
+		s.setErr(err)
+		return
+	}
+
+	for {
+		select {
+		case <-s.quit:
+			return
+		default:
+		}
+
+		line, err := b.ReadBytes(delim)
+		if err != nil {
+			s.setErr(err)
+			return
+		}
+
+		trimmed := bytes.TrimSpace(line)
+
+		// The results array is closed by a "]," line (or, if empty,
+		// just "]"), with "last_seq" following either on the same
+		// line or on the next one; either way there are no more rows
+		// once we see it.
+		if bytes.HasPrefix(trimmed, []byte("]")) {
+			rest := bytes.TrimLeft(trimmed, "],")
+			if len(rest) == 0 {
+				continue // last_seq (if any) follows on the next line
+			}
+			s.parseLastSeq(rest)
+			return
+		}
+		if bytes.HasPrefix(trimmed, []byte("\"last_seq\"")) {
+			s.parseLastSeq(line)
+			return
+		}
+
+		line = bytes.TrimRight(line, ",\r\n")
+		var c Change
+		if err := json.Unmarshal(line, &c); err != nil {
+			s.setErr(err)
+			return
+		}
+
+		select {
+		case s.changes <- c:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// readContinuous parses the continuous framing: one JSON object per
+// line, with no enclosing envelope, terminated by a line containing
+// {"last_seq":...}.
+func (s *ChangeScanner) readContinuous() {
+	defer close(s.changes)
+	defer s.closeBody()
+
+	b := bufio.NewReader(s.resp.Body)
+	for {
+		select {
+		case <-s.quit:
+			return
+		default:
+		}
+
+		line, err := b.ReadBytes(delim)
+		if err != nil {
+			s.setErr(err)
+			return
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue // heartbeat
+		}
+
+		if bytes.Contains(line, []byte("\"last_seq\"")) {
+			s.parseLastSeq(line)
+			return
+		}
+
+		var c Change
+		if err := json.Unmarshal(line, &c); err != nil {
+			s.setErr(err)
+			return
+		}
+
+		select {
+		case s.changes <- c:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// parseLastSeq extracts "last_seq" from line, which may be a full JSON
+// object ({"last_seq":...}) or just its tail ("last_seq":...}), as in
+// the normal/longpoll envelope, whose opening brace was on the first
+// line read.
+func (s *ChangeScanner) parseLastSeq(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+	if line[0] != '{' {
+		line = append([]byte("{"), line...)
+	}
+	var last struct {
+		LastSeq json.RawMessage `json:"last_seq"`
+	}
+	if err := json.Unmarshal(line, &last); err == nil {
+		s.lastSeq = last.LastSeq
+	}
+}
+
+// Scan reads the next change off the feed. It returns false once the
+// feed has ended or an error occurred; callers should check Err in
+// that case.
+func (s *ChangeScanner) Scan() bool {
+	var ok bool
+	select {
+	case s.change, ok = <-s.changes:
+	case <-s.quit:
+		return false
+	}
+	return ok
+}
+
+// Change returns the change read by the most recent call to Scan.
+func (s *ChangeScanner) Change() Change {
+	return s.change
+}
+
+func (s *ChangeScanner) setErr(err error) {
+	if err == io.EOF {
+		return
+	}
+	select {
+	case <-s.quit:
+		return // reads fail once Close has closed the body
+	default:
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Err returns the first non-EOF error encountered while reading the
+// feed, if any.
+func (s *ChangeScanner) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
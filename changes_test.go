@@ -0,0 +1,113 @@
+package couchdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestClient(t *testing.T, body string, contentType string) (*Client, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}))
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, srv.Close
+}
+
+func TestChangesNormal(t *testing.T) {
+	const resp = `{"results":[
+{"seq":1,"id":"doc1","changes":[{"rev":"1-a"}]},
+{"seq":2,"id":"doc2","changes":[{"rev":"1-b"}],"deleted":true}
+],
+"last_seq":2}
+`
+	c, closeSrv := newTestClient(t, resp, "application/json")
+	defer closeSrv()
+
+	s, err := c.DB("db").Changes(Options{"feed": "normal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var got []Change
+	for s.Scan() {
+		got = append(got, s.Change())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected scan error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d changes, want 2", len(got))
+	}
+	if got[0].ID != "doc1" || got[0].Deleted {
+		t.Errorf("got[0] = %+v, want id=doc1 deleted=false", got[0])
+	}
+	if got[1].ID != "doc2" || !got[1].Deleted {
+		t.Errorf("got[1] = %+v, want id=doc2 deleted=true", got[1])
+	}
+	if string(s.LastSeq()) != "2" {
+		t.Errorf("LastSeq() = %s, want 2", s.LastSeq())
+	}
+}
+
+func TestChangesNormalEmpty(t *testing.T) {
+	const resp = `{"results":[
+],
+"last_seq":0}
+`
+	c, closeSrv := newTestClient(t, resp, "application/json")
+	defer closeSrv()
+
+	s, err := c.DB("db").Changes(Options{"feed": "normal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if s.Scan() {
+		t.Fatalf("Scan returned true on an empty result set: %+v", s.Change())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected scan error: %v", err)
+	}
+	if string(s.LastSeq()) != "0" {
+		t.Errorf("LastSeq() = %s, want 0", s.LastSeq())
+	}
+}
+
+func TestChangesContinuous(t *testing.T) {
+	const resp = "{\"seq\":1,\"id\":\"doc1\",\"changes\":[{\"rev\":\"1-a\"}]}\n" +
+		"\n" + // heartbeat
+		"{\"seq\":2,\"id\":\"doc2\",\"changes\":[{\"rev\":\"1-b\"}]}\n" +
+		"{\"last_seq\":2}\n"
+	c, closeSrv := newTestClient(t, resp, "application/json")
+	defer closeSrv()
+
+	s, err := c.DB("db").Changes(Options{"feed": "continuous"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var ids []string
+	for s.Scan() {
+		ids = append(ids, s.Change().ID)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected scan error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "doc1" || ids[1] != "doc2" {
+		t.Fatalf("got ids %v, want [doc1 doc2]", ids)
+	}
+	if string(s.LastSeq()) != "2" {
+		t.Errorf("LastSeq() = %s, want 2", s.LastSeq())
+	}
+}
@@ -0,0 +1,112 @@
+package couchdb
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ClientOptions configures the retry/backoff behavior and
+// instrumentation hooks used by a Client's underlying transport.
+type ClientOptions struct {
+	// MaxRetries is the maximum number of retry attempts for a failed
+	// request, not counting the initial attempt. Zero means use the
+	// package default.
+	MaxRetries int
+
+	// InitialBackoff is the wait before the first retry; it doubles
+	// after each subsequent attempt, up to MaxBackoff. Zero means use
+	// the package default.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between retries. Zero means use the
+	// package default.
+	MaxBackoff time.Duration
+
+	// RetryOn decides whether a request should be retried given the
+	// response (nil on a transport-level error) and error of an
+	// attempt. Only idempotent requests (GET/HEAD, and PUT/DELETE that
+	// carry a "rev" parameter) are ever retried, regardless of what
+	// RetryOn returns. Nil means use the package default, which retries
+	// connection errors and 500/502/503/504/429 responses.
+	RetryOn func(resp *http.Response, err error) bool
+
+	// OnRequest, if set, is called before a request is first sent; retry
+	// attempts after a transient failure do not call it again.
+	OnRequest func(method, path string)
+
+	// OnResponse, if set, is called after every outgoing HTTP request
+	// (including ones that exhausted their retries) with the final
+	// status code (zero if the request never got a response), the
+	// total duration spent including retries, and the final error.
+	OnResponse func(method, path string, status int, dur time.Duration, err error)
+}
+
+// defaultMaxRetries, defaultInitialBackoff and defaultMaxBackoff mirror
+// the retry behavior Fabric's CouchDB client uses in production.
+const (
+	defaultMaxRetries     = 4
+	defaultInitialBackoff = 125 * time.Millisecond
+	defaultMaxBackoff     = 2 * time.Second
+)
+
+func defaultClientOptions() ClientOptions {
+	return ClientOptions{
+		MaxRetries:     defaultMaxRetries,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		RetryOn:        defaultRetryOn,
+	}
+}
+
+func (o ClientOptions) withDefaults() ClientOptions {
+	d := defaultClientOptions()
+	if o.MaxRetries != 0 {
+		d.MaxRetries = o.MaxRetries
+	}
+	if o.InitialBackoff != 0 {
+		d.InitialBackoff = o.InitialBackoff
+	}
+	if o.MaxBackoff != 0 {
+		d.MaxBackoff = o.MaxBackoff
+	}
+	if o.RetryOn != nil {
+		d.RetryOn = o.RetryOn
+	}
+	d.OnRequest = o.OnRequest
+	d.OnResponse = o.OnResponse
+	return d
+}
+
+func defaultRetryOn(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+		http.StatusTooManyRequests:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewClientWithOptions is like NewClient but lets the caller configure
+// retry/backoff behavior and instrumentation hooks; see ClientOptions.
+func NewClientWithOptions(rawurl string, rt http.RoundTripper, opts ClientOptions) (*Client, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery, u.Fragment = "", ""
+	var auth Auth
+	if u.User != nil {
+		passwd, _ := u.User.Password()
+		auth = BasicAuth(u.User.Username(), passwd)
+		u.User = nil
+	}
+	t := newTransport(u.String(), rt, auth)
+	t.opts = opts.withDefaults()
+	return &Client{t}, nil
+}
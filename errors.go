@@ -0,0 +1,48 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// Error is returned for requests that result in an unexpected HTTP status.
+type Error struct {
+	Method     string // HTTP method of the request
+	URL        string // HTTP request URL
+	StatusCode int    // HTTP status code
+	Reason     string // Reason from the CouchDB response, if any
+
+	// Header fields of the response.
+	Header http.Header
+}
+
+func (e *Error) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%s %s: %s", e.Method, e.URL, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%s %s: %s (%s)", e.Method, e.URL, http.StatusText(e.StatusCode), e.Reason)
+}
+
+// ErrorStatus checks whether err is an *Error with the given HTTP status
+// code. It is commonly used to check for the status codes that signal an
+// expected outcome, e.g. "404 Not Found" for Get or "412 Precondition
+// Failed" for CreateDB.
+func ErrorStatus(err error, status int) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == status
+}
+
+func parseError(method, url string, resp *http.Response) error {
+	e := &Error{Method: method, URL: url, StatusCode: resp.StatusCode, Header: resp.Header}
+	defer resp.Body.Close()
+	var reason struct {
+		Reason string `json:"reason"`
+	}
+	if body, err := ioutil.ReadAll(resp.Body); err == nil {
+		json.Unmarshal(body, &reason)
+	}
+	e.Reason = reason.Reason
+	return e
+}
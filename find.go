@@ -0,0 +1,257 @@
+package couchdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Query describes a Mango query for Find/FindScanner.
+//
+// http://docs.couchdb.org/en/latest/api/database/find.html
+type Query struct {
+	Selector       map[string]interface{} `json:"selector"`
+	Fields         []string               `json:"fields,omitempty"`
+	Sort           []map[string]string    `json:"sort,omitempty"`
+	Limit          int                    `json:"limit,omitempty"`
+	Skip           int                    `json:"skip,omitempty"`
+	UseIndex       interface{}            `json:"use_index,omitempty"`
+	Bookmark       string                 `json:"bookmark,omitempty"`
+	ExecutionStats bool                   `json:"execution_stats,omitempty"`
+}
+
+// FindResult is the body returned by Find.
+type FindResult struct {
+	Docs           []json.RawMessage `json:"docs"`
+	Bookmark       string            `json:"bookmark"`
+	Warning        string            `json:"warning,omitempty"`
+	ExecutionStats *ExecutionStats   `json:"execution_stats,omitempty"`
+}
+
+// ExecutionStats reports the index usage of a Mango query, present
+// when Query.ExecutionStats is set.
+type ExecutionStats struct {
+	TotalKeysExamined       int     `json:"total_keys_examined"`
+	TotalDocsExamined       int     `json:"total_docs_examined"`
+	TotalQuorumDocsExamined int     `json:"total_quorum_docs_examined"`
+	ResultsReturned         int     `json:"results_returned"`
+	ExecutionTimeMs         float64 `json:"execution_time_ms"`
+}
+
+// Find runs a Mango query against the database's _find endpoint and
+// unmarshals the matching documents into result, which must be a
+// pointer to a slice.
+//
+// http://docs.couchdb.org/en/latest/api/database/find.html#db-find
+func (db *DB) Find(query Query, result interface{}) error {
+	res, err := db.find(query)
+	if err != nil {
+		return err
+	}
+	enc, err := json.Marshal(res.Docs)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(enc, result)
+}
+
+// FindStats is like Find but also returns the execution_stats reported
+// by CouchDB when query.ExecutionStats is set.
+func (db *DB) FindStats(query Query, result interface{}) (*ExecutionStats, error) {
+	res, err := db.find(query)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := json.Marshal(res.Docs)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(enc, result); err != nil {
+		return nil, err
+	}
+	return res.ExecutionStats, nil
+}
+
+func (db *DB) find(query Query) (*FindResult, error) {
+	enc, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := db.request("POST", path(db.name, "_find"), bytes.NewReader(enc))
+	if err != nil {
+		return nil, err
+	}
+	var res FindResult
+	if err := readBody(resp, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// FindScanner is like Find but streams matching documents as Rows
+// instead of unmarshalling them all into memory at once. Each Row's
+// Doc holds one matched document; Row.ID/Key are not populated since
+// _find does not return them.
+func (db *DB) FindScanner(query Query) (*RowScanner, error) {
+	enc, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := db.request("POST", path(db.name, "_find"), bytes.NewReader(enc))
+	if err != nil {
+		return nil, err
+	}
+	return newFindScanner(resp), nil
+}
+
+func newFindScanner(resp *http.Response) *RowScanner {
+	s := &RowScanner{
+		resp: resp,
+		quit: make(chan struct{}),
+		rows: make(chan Row),
+		mu:   new(sync.Mutex),
+	}
+	go s.readFindLoop()
+	return s
+}
+
+// readFindLoop parses the _find response framing:
+// {"docs":[ {...},\n {...} ],"bookmark":"..."}
+func (s *RowScanner) readFindLoop() {
+	defer close(s.rows)
+	defer func() {
+		io.Copy(ioutil.Discard, s.resp.Body)
+		s.resp.Body.Close()
+	}()
+
+	b := bufio.NewReader(s.resp.Body)
+	if _, err := b.ReadBytes(delim); err != nil { // {"docs":[
+		s.setErr(err)
+		return
+	}
+
+	for {
+		select {
+		case <-s.quit:
+			return
+		default:
+		}
+
+		line, err := b.ReadBytes(delim)
+		if err != nil {
+			s.setErr(err)
+			return
+		}
+
+		trimmed := bytes.TrimSpace(line)
+		if bytes.HasPrefix(trimmed, []byte("],")) || bytes.Equal(trimmed, []byte("]")) {
+			return
+		}
+
+		last := bytes.HasSuffix(line, endMarker)
+		line = bytes.TrimRight(line, ",\r\n")
+
+		var doc json.RawMessage
+		if err := json.Unmarshal(line, &doc); err != nil {
+			s.setErr(err)
+			return
+		}
+		row := Row{Doc_: &doc}
+
+		select {
+		case s.rows <- row:
+			if last {
+				return
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// IndexDef describes a Mango index to create with CreateIndex.
+type IndexDef struct {
+	Index struct {
+		Fields []map[string]string `json:"fields"`
+	} `json:"index"`
+	Ddoc string `json:"ddoc,omitempty"`
+	Name string `json:"name,omitempty"`
+	Type string `json:"type,omitempty"` // "json" (default) or "text"
+}
+
+// NewIndex builds an IndexDef for a simple ascending-field index, the
+// most common case; for descending fields, set Index.Fields of an
+// IndexDef directly. IndexDef has no field for a partial-index filter.
+func NewIndex(fields ...string) IndexDef {
+	var idx IndexDef
+	for _, f := range fields {
+		idx.Index.Fields = append(idx.Index.Fields, map[string]string{f: "asc"})
+	}
+	return idx
+}
+
+type createIndexResponse struct {
+	Result string `json:"result"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+}
+
+// CreateIndex creates a Mango index and returns the name of the index
+// and the design document it was stored under.
+//
+// http://docs.couchdb.org/en/latest/api/database/find.html#db-index
+func (db *DB) CreateIndex(idx IndexDef) (name, ddoc string, err error) {
+	enc, err := json.Marshal(idx)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := db.request("POST", path(db.name, "_index"), bytes.NewReader(enc))
+	if err != nil {
+		return "", "", err
+	}
+	var res createIndexResponse
+	if err := readBody(resp, &res); err != nil {
+		return "", "", err
+	}
+	return res.Name, res.ID, nil
+}
+
+// DeleteIndex removes a Mango index.
+//
+// http://docs.couchdb.org/en/latest/api/database/find.html#delete--db-_index-designdoc-json-name
+func (db *DB) DeleteIndex(ddoc, name string) error {
+	_, err := db.closedRequest("DELETE", path(db.name, "_index", ddoc, "json", name), nil)
+	return err
+}
+
+// IndexInfo describes one index as returned by ListIndexes.
+type IndexInfo struct {
+	Ddoc string `json:"ddoc"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Def  struct {
+		Fields []map[string]string `json:"fields"`
+	} `json:"def"`
+}
+
+// ListIndexes returns all Mango indexes defined on the database,
+// including the implicit "_all_docs" index.
+//
+// http://docs.couchdb.org/en/latest/api/database/find.html#get--db-_index
+func (db *DB) ListIndexes() ([]IndexInfo, error) {
+	resp, err := db.request("GET", path(db.name, "_index"), nil)
+	if err != nil {
+		return nil, err
+	}
+	var res struct {
+		Indexes []IndexInfo `json:"indexes"`
+	}
+	if err := readBody(resp, &res); err != nil {
+		return nil, err
+	}
+	return res.Indexes, nil
+}
@@ -0,0 +1,64 @@
+package couchdb
+
+import "testing"
+
+func TestFindScannerDocs(t *testing.T) {
+	const resp = `{"docs":[
+{"_id":"doc1","name":"alice"},
+{"_id":"doc2","name":"bob"}
+],
+"bookmark":"g1A"}
+`
+	c, closeSrv := newTestClient(t, resp, "application/json")
+	defer closeSrv()
+
+	s, err := c.DB("db").FindScanner(Query{Selector: map[string]interface{}{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var names []string
+	for s.Scan() {
+		row := s.Row()
+		if !row.HasDoc() {
+			t.Fatalf("row %+v has no doc", row)
+		}
+		var doc struct {
+			ID   string `json:"_id"`
+			Name string `json:"name"`
+		}
+		if err := row.Doc(&doc); err != nil {
+			t.Fatalf("Doc: %v", err)
+		}
+		names = append(names, doc.Name)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected scan error: %v", err)
+	}
+	if len(names) != 2 || names[0] != "alice" || names[1] != "bob" {
+		t.Fatalf("got names %v, want [alice bob]", names)
+	}
+}
+
+func TestFindScannerEmpty(t *testing.T) {
+	const resp = `{"docs":[
+],
+"bookmark":"g1A"}
+`
+	c, closeSrv := newTestClient(t, resp, "application/json")
+	defer closeSrv()
+
+	s, err := c.DB("db").FindScanner(Query{Selector: map[string]interface{}{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if s.Scan() {
+		t.Fatalf("Scan returned true on an empty docs array: %+v", s.Row())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected scan error: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// Options represents a set of query options, e.g. for View or Get.
+// Values that are listed as needing JSON encoding (like "startkey")
+// are marshalled to JSON before being added to the query string;
+// of all other values, strings are used as they are and anything else
+// is marshalled to JSON as well.
+type Options map[string]interface{}
+
+// path joins url-escaped segments into a slash-separated path that
+// starts with a slash.
+func path(segments ...string) string {
+	b := make([]byte, 0, 64)
+	for _, s := range segments {
+		b = append(b, '/')
+		b = append(b, []byte(url.QueryEscape(s))...)
+	}
+	return string(b)
+}
+
+// revpath is like path but appends a "?rev=" query string when rev is
+// not empty.
+func revpath(rev string, segments ...string) string {
+	p := path(segments...)
+	if rev != "" {
+		p += "?rev=" + url.QueryEscape(rev)
+	}
+	return p
+}
+
+// optpath is like path but appends the query string built from opts.
+// jsonKeys lists the option keys whose values must be JSON-encoded even
+// when they are strings, e.g. CouchDB's "startkey"/"endkey"; string
+// values of other keys are used as they are.
+func optpath(opts Options, jsonKeys []string, segments ...string) (string, error) {
+	p := path(segments...)
+	if len(opts) == 0 {
+		return p, nil
+	}
+	isJSONKey := make(map[string]bool, len(jsonKeys))
+	for _, k := range jsonKeys {
+		isJSONKey[k] = true
+	}
+	values := make(url.Values, len(opts))
+	for k, v := range opts {
+		if isJSONKey[k] {
+			enc, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			values.Set(k, string(enc))
+		} else if s, ok := v.(string); ok {
+			values.Set(k, s)
+		} else {
+			enc, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			values.Set(k, string(enc))
+		}
+	}
+	return p + "?" + values.Encode(), nil
+}
+
+func isTrue(opts Options, key string) bool {
+	v, ok := opts[key].(bool)
+	return ok && v
+}
+
+func stringOpt(opts Options, key string) (string, bool) {
+	if opts == nil {
+		return "", false
+	}
+	v, ok := opts[key].(string)
+	return v, ok
+}
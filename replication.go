@@ -0,0 +1,149 @@
+package couchdb
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ReplicationEndpoint identifies the source or target of a
+// replication. A plain database name on the same server can be given
+// as a bare string; for a remote server, use a full URL (optionally
+// with embedded credentials).
+type ReplicationEndpoint struct {
+	URL string
+}
+
+// MarshalJSON implements json.Marshaler so a ReplicationEndpoint is
+// encoded as a bare string, matching the format CouchDB expects for
+// "source"/"target".
+func (e ReplicationEndpoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.URL)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the inverse of
+// MarshalJSON, so a ReplicationSpec read back from a replication
+// document (e.g. via GetReplication) can be unmarshalled.
+func (e *ReplicationEndpoint) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &e.URL)
+}
+
+// ReplicationSpec describes a replication job, for use with
+// Client.Replicate and Client.PutReplication.
+type ReplicationSpec struct {
+	Source       ReplicationEndpoint `json:"source"`
+	Target       ReplicationEndpoint `json:"target"`
+	Continuous   bool                `json:"continuous,omitempty"`
+	CreateTarget bool                `json:"create_target,omitempty"`
+	DocIDs       []string            `json:"doc_ids,omitempty"`
+	Filter       string              `json:"filter,omitempty"`
+	Cancel       bool                `json:"cancel,omitempty"`
+}
+
+// ReplicationResult is the response to a one-off replication request.
+// History and ReplicationIDVersion are only populated once the
+// replication has finished; for continuous replications, Ok is the
+// only field that is meaningful.
+type ReplicationResult struct {
+	Ok                   bool               `json:"ok"`
+	SessionID            string             `json:"session_id"`
+	SourceLastSeq        *json.RawMessage   `json:"source_last_seq"`
+	ReplicationIDVersion int                `json:"replication_id_version"`
+	History              []ReplicationEvent `json:"history"`
+}
+
+// ReplicationEvent is one entry of a ReplicationResult's History.
+type ReplicationEvent struct {
+	SessionID        string `json:"session_id"`
+	StartTime        string `json:"start_time"`
+	EndTime          string `json:"end_time"`
+	MissingChecked   int    `json:"missing_checked"`
+	MissingFound     int    `json:"missing_found"`
+	DocsRead         int    `json:"docs_read"`
+	DocsWritten      int    `json:"docs_written"`
+	DocWriteFailures int    `json:"doc_write_failures"`
+}
+
+// Replicate starts (or, with Cancel set, stops) a one-off replication
+// via the server's /_replicate endpoint. For a durable, persisted
+// replication job, use PutReplication instead.
+//
+// http://docs.couchdb.org/en/latest/api/server/common.html#replicate
+func (c *Client) Replicate(spec ReplicationSpec) (*ReplicationResult, error) {
+	enc, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.request("POST", "/_replicate", bytes.NewReader(enc))
+	if err != nil {
+		return nil, err
+	}
+	var res ReplicationResult
+	if err := readBody(resp, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// replicatorDB is the well-known database CouchDB watches for durable
+// replication documents.
+const replicatorDB = "_replicator"
+
+// PutReplication creates or updates a durable replication job by
+// storing spec as a document with the given id in the _replicator
+// database. CouchDB picks up the document and manages the replication
+// job for as long as it exists. rev must be the current revision of
+// the document when updating an existing job, or empty when creating
+// a new one; it follows the same convention as DB.Put.
+//
+// http://docs.couchdb.org/en/latest/replication/replicator.html
+func (c *Client) PutReplication(id, rev string, spec ReplicationSpec) (newrev string, err error) {
+	return c.DB(replicatorDB).Put(id, spec, rev)
+}
+
+// GetReplication retrieves a durable replication job's document.
+func (c *Client) GetReplication(id string, doc interface{}) error {
+	return c.DB(replicatorDB).Get(id, doc, nil)
+}
+
+// DeleteReplication removes a durable replication job, stopping it.
+func (c *Client) DeleteReplication(id, rev string) error {
+	_, err := c.DB(replicatorDB).Delete(id, rev)
+	return err
+}
+
+// Task describes one entry of Client.ActiveTasks. Fields vary by task
+// type (replication, database_compaction, view_compaction, indexer);
+// Type indicates which one this entry is, and only the fields relevant
+// to that type are populated.
+type Task struct {
+	Type      string `json:"type"`
+	PID       string `json:"pid"`
+	Node      string `json:"node,omitempty"`
+	Database  string `json:"database,omitempty"`
+	Progress  int    `json:"progress,omitempty"`
+	StartedOn int64  `json:"started_on"`
+	UpdatedOn int64  `json:"updated_on"`
+
+	// Replication-specific fields.
+	Source      string `json:"source,omitempty"`
+	Target      string `json:"target,omitempty"`
+	Continuous  bool   `json:"continuous,omitempty"`
+	DocsRead    int    `json:"docs_read,omitempty"`
+	DocsWritten int    `json:"docs_written,omitempty"`
+}
+
+// ActiveTasks lists all tasks (replications, compactions, indexing)
+// currently running on the server, so operators can monitor progress.
+//
+// http://docs.couchdb.org/en/latest/api/server/common.html#active-tasks
+func (c *Client) ActiveTasks() ([]Task, error) {
+	resp, err := c.request("GET", "/_active_tasks", nil)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []Task
+	if err := readBody(resp, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
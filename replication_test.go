@@ -0,0 +1,49 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReplicationEndpointRoundtrip(t *testing.T) {
+	spec := ReplicationSpec{
+		Source: ReplicationEndpoint{URL: "http://src.example.com/db"},
+		Target: ReplicationEndpoint{URL: "dst"},
+	}
+	enc, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got ReplicationSpec
+	if err := json.Unmarshal(enc, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Source.URL != spec.Source.URL || got.Target.URL != spec.Target.URL {
+		t.Errorf("got %+v, want %+v", got, spec)
+	}
+}
+
+func TestGetReplication(t *testing.T) {
+	const resp = `{"_id":"job1","_rev":"1-a","source":"src","target":"dst","continuous":true}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(resp))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spec ReplicationSpec
+	if err := c.GetReplication("job1", &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Source.URL != "src" || spec.Target.URL != "dst" || !spec.Continuous {
+		t.Errorf("got %+v", spec)
+	}
+}
@@ -0,0 +1,34 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// readBody decodes the JSON response body into v and closes the body.
+func readBody(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// responseRev extracts the revision of a document from the ETag header
+// of resp. It does not read or close resp.Body; it is used with
+// closedRequest responses by Put, Delete, Rev and the attachment
+// methods, which don't need the response body.
+func responseRev(resp *http.Response, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	rev := resp.Header.Get("Etag")
+	if rev == "" {
+		return "", errors.New("couchdb: missing Etag header in response")
+	}
+	return strings.Trim(rev, `"`), nil
+}
@@ -0,0 +1,111 @@
+package couchdb
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// roundTrip sends req, retrying on transient failures for idempotent
+// requests according to t.opts, and reports OnRequest/OnResponse if
+// configured. method and path are passed separately (rather than read
+// off req) purely for the instrumentation hooks and the idempotency
+// check, since path here is the request-relative path used elsewhere
+// in this package, not req.URL's full form.
+func (t *transport) roundTrip(method, path string, req *http.Request) (*http.Response, error) {
+	opts := t.opts
+	if opts.OnRequest != nil {
+		opts.OnRequest(method, path)
+	}
+
+	start := time.Now()
+	retryable := isIdempotent(method, path)
+	backoff := opts.InitialBackoff
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = t.rt.RoundTrip(req)
+
+		if !retryable || attempt >= opts.MaxRetries || !opts.RetryOn(resp, err) {
+			break
+		}
+		if req.Body != nil {
+			if req.GetBody == nil {
+				break // body can't be resent; give up with the last result
+			}
+			body, berr := req.GetBody()
+			if berr != nil {
+				break
+			}
+			req.Body = body
+		}
+
+		wait := backoff
+		if resp != nil {
+			if resp.StatusCode == http.StatusTooManyRequests {
+				if ra, ok := retryAfter(resp); ok {
+					wait = ra
+				}
+			}
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		time.Sleep(jitter(wait))
+		if backoff *= 2; backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+
+	if opts.OnResponse != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		opts.OnResponse(method, path, status, time.Since(start), err)
+	}
+	return resp, err
+}
+
+// isIdempotent reports whether method/path is safe to retry: GET and
+// HEAD always are, PUT and DELETE are only when they target a specific
+// revision (a "rev=" query parameter), which rules out retrying a PUT
+// that creates a new document (a retry after a lost response could
+// otherwise create a duplicate).
+func isIdempotent(method, path string) bool {
+	switch method {
+	case "GET", "HEAD":
+		return true
+	case "PUT", "DELETE":
+		return strings.Contains(path, "rev=")
+	default:
+		return false
+	}
+}
+
+// retryAfter parses a Retry-After header expressed in seconds, as
+// CouchDB sends it on 429 responses.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	secs, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+// jitter returns d plus up to 50% random extra delay, to keep many
+// clients retrying against the same server from synchronizing.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
@@ -0,0 +1,129 @@
+package couchdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsIdempotent(t *testing.T) {
+	cases := []struct {
+		method, path string
+		want         bool
+	}{
+		{"GET", "/db/doc1", true},
+		{"HEAD", "/db/doc1", true},
+		{"PUT", "/db/doc1?rev=1-a", true},
+		{"DELETE", "/db/doc1?rev=1-a", true},
+		{"PUT", "/db/doc1", false},
+		{"DELETE", "/db/doc1", false},
+		{"POST", "/db/_bulk_docs", false},
+	}
+	for _, c := range cases {
+		if got := isIdempotent(c.method, c.path); got != c.want {
+			t.Errorf("isIdempotent(%q, %q) = %v, want %v", c.method, c.path, got, c.want)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Retry-After": {"2"}}}
+	d, ok := retryAfter(resp)
+	if !ok || d != 2*time.Second {
+		t.Errorf("retryAfter = %v, %v, want 2s, true", d, ok)
+	}
+
+	resp = &http.Response{Header: http.Header{}}
+	if _, ok := retryAfter(resp); ok {
+		t.Errorf("retryAfter with no header should report false")
+	}
+
+	resp = &http.Response{Header: http.Header{"Retry-After": {"not-a-number"}}}
+	if _, ok := retryAfter(resp); ok {
+		t.Errorf("retryAfter with malformed header should report false")
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if got := jitter(0); got != 0 {
+		t.Errorf("jitter(0) = %v, want 0", got)
+	}
+	d := 100 * time.Millisecond
+	for i := 0; i < 50; i++ {
+		got := jitter(d)
+		if got < d || got > d+d/2 {
+			t.Fatalf("jitter(%v) = %v, want in [%v, %v]", d, got, d, d+d/2)
+		}
+	}
+}
+
+func TestRoundTripRetriesIdempotentRequest(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	var requests, responses int
+	var lastStatus int
+	c, err := NewClientWithOptions(srv.URL, nil, ClientOptions{
+		MaxRetries:     5,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		OnRequest:      func(method, path string) { requests++ },
+		OnResponse: func(method, path string, status int, dur time.Duration, err error) {
+			responses++
+			lastStatus = status
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]interface{}
+	if err := c.DB("db").Get("doc1", &doc, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d server calls, want 3", calls)
+	}
+	if requests != 1 {
+		t.Errorf("OnRequest called %d times, want 1 (once per logical request, not per attempt)", requests)
+	}
+	if responses != 1 || lastStatus != http.StatusOK {
+		t.Errorf("OnResponse called %d times with last status %d, want 1 call with 200", responses, lastStatus)
+	}
+}
+
+func TestRoundTripDoesNotRetryNonIdempotentRequest(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c, err := NewClientWithOptions(srv.URL, nil, ClientOptions{
+		MaxRetries:     5,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.DB("db").BulkDocs([]interface{}{map[string]string{"a": "1"}}, nil)
+	if err == nil {
+		t.Fatal("expected an error from a persistent 503")
+	}
+	if calls != 1 {
+		t.Fatalf("got %d server calls, want 1 (POST is not idempotent)", calls)
+	}
+}
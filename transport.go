@@ -0,0 +1,201 @@
+package couchdb
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// transport implements the low-level HTTP mechanics shared by Client
+// and DB: building the request URL, attaching authentication, and
+// turning non-2xx responses into errors.
+type transport struct {
+	prefix string // URL prefix, without trailing slash
+
+	mu   sync.RWMutex
+	auth Auth
+
+	rt   http.RoundTripper
+	opts ClientOptions
+}
+
+func newTransport(prefix string, rt http.RoundTripper, auth Auth) *transport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	t := &transport{prefix: strings.TrimSuffix(prefix, "/"), rt: rt, auth: auth, opts: defaultClientOptions()}
+	if rts, ok := auth.(roundTripperSetter); ok {
+		rts.setRoundTripper(rt)
+	}
+	return t
+}
+
+func (t *transport) setAuth(a Auth) {
+	t.mu.Lock()
+	t.auth = a
+	t.mu.Unlock()
+	if rts, ok := a.(roundTripperSetter); ok {
+		rts.setRoundTripper(t.rt)
+	}
+}
+
+// roundTripperSetter is implemented by Auth mechanisms that need the
+// client's own RoundTripper for requests they make outside the normal
+// request path, e.g. cookieAuth's login/logout, so a custom transport
+// (TLS client certs, a proxy, a mock used in tests) applies uniformly
+// to the session handshake and not just ordinary requests.
+type roundTripperSetter interface {
+	Auth
+	setRoundTripper(rt http.RoundTripper)
+}
+
+func (t *transport) getAuth() Auth {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.auth
+}
+
+// request issues an HTTP request with a JSON body (or no body) and
+// returns the response if the status code indicates success.
+func (t *transport) request(method, path string, body io.Reader) (*http.Response, error) {
+	return t.requestWithContentType(method, path, body, "application/json")
+}
+
+// requestWithContentType is like request but lets the caller override
+// the Content-Type header, which is needed for multipart request
+// bodies such as attachment uploads.
+func (t *transport) requestWithContentType(method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	return t.requestWithTypes(method, path, body, contentType, "application/json")
+}
+
+// requestWithAccept is like request but lets the caller override the
+// Accept header, which is needed to ask CouchDB for a multipart/related
+// response (see GetWithAttachments) instead of the default JSON.
+func (t *transport) requestWithAccept(method, path string, body io.Reader, accept string) (*http.Response, error) {
+	return t.requestWithTypes(method, path, body, "application/json", accept)
+}
+
+// requestWithTypes is the shared implementation behind request,
+// requestWithContentType and requestWithAccept. Every request made
+// through a Client or DB funnels through here (and, beneath it,
+// doRequest and roundTrip), so cookie re-authentication, session
+// refresh, retry/backoff and the OnRequest/OnResponse hooks all apply
+// uniformly regardless of which of those three entry points a caller
+// used. cookieAuth's own /_session login and logout requests go
+// straight to the RoundTripper and bypass all of this.
+//
+// Bodies that need to be sent again after a 401 retry must implement
+// io.Seeker so rewind can reset them to the start; requestWithTypes
+// only retries requests with a nil body or one that seeks, such as a
+// *bytes.Reader or *strings.Reader. Note that *bytes.Buffer does NOT
+// implement io.Seeker — callers with a Buffer should pass
+// bytes.NewReader(buf.Bytes()) instead. Every call site in this
+// package does.
+func (t *transport) requestWithTypes(method, path string, body io.Reader, contentType, accept string) (*http.Response, error) {
+	resp, err := t.doRequest(method, path, body, contentType, accept)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		if ra, ok := t.getAuth().(reauthenticator); ok {
+			ra.invalidate()
+			if retryBody, ok := rewind(body); ok {
+				resp2, err2 := t.doRequest(method, path, retryBody, contentType, accept)
+				if err2 != nil {
+					return nil, err2
+				}
+				if resp2.StatusCode == http.StatusUnauthorized {
+					return nil, parseError(method, path, resp2)
+				}
+				return resp2, nil
+			}
+		}
+		return nil, parseError(method, path, resp)
+	}
+	return resp, nil
+}
+
+// reauthenticator is implemented by Auth mechanisms that can recover
+// from a 401 by discarding cached credentials, e.g. CookieAuth.
+type reauthenticator interface {
+	Auth
+	invalidate()
+}
+
+func (t *transport) doRequest(method, path string, body io.Reader, contentType, accept string) (*http.Response, error) {
+	req, err := http.NewRequest(method, t.prefix+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", accept)
+	if auth := t.getAuth(); auth != nil {
+		if err := auth.AddAuth(req); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := t.roundTrip(method, path, req)
+	if err != nil {
+		return nil, err
+	}
+	if auth, ok := t.getAuth().(sessionUpdater); ok {
+		auth.updateFromResponse(resp)
+	}
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusUnauthorized {
+		return nil, parseError(method, path, resp)
+	}
+	return resp, nil
+}
+
+// sessionUpdater is implemented by Auth mechanisms that can pick up a
+// refreshed Set-Cookie from an ordinary API response, e.g. CookieAuth.
+type sessionUpdater interface {
+	Auth
+	updateFromResponse(resp *http.Response)
+}
+
+// rewind resets r to its start so it can be sent again after a retry,
+// if it supports seeking.
+func rewind(r io.Reader) (io.Reader, bool) {
+	if r == nil {
+		return nil, true
+	}
+	s, ok := r.(io.Seeker)
+	if !ok {
+		return nil, false
+	}
+	if _, err := s.Seek(0, io.SeekStart); err != nil {
+		return nil, false
+	}
+	return r, true
+}
+
+// closedRequest is like request but closes the response body after
+// reading it, for callers that only care about headers or status.
+func (t *transport) closedRequest(method, path string, body io.Reader) (*http.Response, error) {
+	resp, err := t.request(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	return resp, nil
+}
+
+// closedRequestWithContentType is like closedRequest but lets the
+// caller override the Content-Type header, for write-only requests
+// (such as an attachment PUT) whose body isn't application/json and
+// whose response is only consulted for its headers.
+func (t *transport) closedRequestWithContentType(method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	resp, err := t.requestWithContentType(method, path, body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	return resp, nil
+}